@@ -0,0 +1,74 @@
+package main
+
+import "math"
+
+// ElevationIndex is a uniform grid over ElevationPoints keyed by
+// (floor(lat/cell), floor(lon/cell)). It replaces the O(N·M) linear scan
+// interpolateElevation used to perform: querying the 9 cells around a
+// point is enough since cell matches the IDW search radius, so any point
+// within range falls in the center cell or one of its neighbors.
+type ElevationIndex struct {
+	cell    float64
+	buckets map[[2]int][]ElevationPoint
+}
+
+// NewElevationIndex buckets points into a grid with cell size equal to
+// the IDW search radius used by Lookup.
+func NewElevationIndex(points []ElevationPoint) *ElevationIndex {
+	idx := &ElevationIndex{
+		cell:    0.1,
+		buckets: make(map[[2]int][]ElevationPoint),
+	}
+	for _, p := range points {
+		key := idx.keyFor(p.Lat, p.Lon)
+		idx.buckets[key] = append(idx.buckets[key], p)
+	}
+	return idx
+}
+
+func (idx *ElevationIndex) keyFor(lat, lon float64) [2]int {
+	return [2]int{int(math.Floor(lat / idx.cell)), int(math.Floor(lon / idx.cell))}
+}
+
+// Lookup performs inverse-distance-weighted interpolation over the 9
+// cells surrounding (lat, lon), matching the maxDistance radius that
+// interpolateElevation used to scan the full point set for.
+func (idx *ElevationIndex) Lookup(lat, lon float64) float64 {
+	if len(idx.buckets) == 0 {
+		return 0
+	}
+
+	maxDistance := idx.cell
+	weightSum := 0.0
+	valueSum := 0.0
+	foundNearby := false
+
+	centerKey := idx.keyFor(lat, lon)
+	for dLat := -1; dLat <= 1; dLat++ {
+		for dLon := -1; dLon <= 1; dLon++ {
+			key := [2]int{centerKey[0] + dLat, centerKey[1] + dLon}
+			for _, ep := range idx.buckets[key] {
+				dLatf := lat - ep.Lat
+				dLonf := lon - ep.Lon
+				distance := math.Sqrt(dLatf*dLatf + dLonf*dLonf)
+
+				if distance < 0.0001 {
+					return ep.Ele
+				}
+
+				if distance < maxDistance {
+					foundNearby = true
+					weight := 1 / (distance * distance)
+					weightSum += weight
+					valueSum += weight * ep.Ele
+				}
+			}
+		}
+	}
+
+	if foundNearby && weightSum > 0 {
+		return valueSum / weightSum
+	}
+
+	return 0
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// limitPolygon is one polygon (outer ring plus any holes) with
+// coordinates already converted to game-coord (x, z) space, so
+// clipping compares directly against Road.Points and POI.X/Z.
+type limitPolygon struct {
+	rings [][][2]float64 // rings[0] is the outer ring, rest are holes
+}
+
+// loadLimitPolygon reads a GeoJSON Polygon, MultiPolygon, Feature, or
+// FeatureCollection from path and returns the polygon(s) it describes,
+// converted to game coordinates via toGameCoords. For a
+// FeatureCollection only the first feature's geometry is read; the
+// other features are ignored. Mirrors the role of imposm3's
+// limit.Limiter, but the clipping itself lives in clipRoadsToPolygons
+// and filterPOIsToPolygons below.
+func loadLimitPolygon(path string) ([]limitPolygon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+		Features    []struct {
+			Geometry json.RawMessage `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	geomType := doc.Type
+	coords := doc.Coordinates
+	if doc.Type == "Feature" {
+		var geom struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		}
+		if err := json.Unmarshal(doc.Geometry, &geom); err != nil {
+			return nil, err
+		}
+		geomType, coords = geom.Type, geom.Coordinates
+	} else if doc.Type == "FeatureCollection" {
+		if len(doc.Features) == 0 {
+			return nil, fmt.Errorf("-limit-to GeoJSON has no features")
+		}
+		var geom struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		}
+		if err := json.Unmarshal(doc.Features[0].Geometry, &geom); err != nil {
+			return nil, err
+		}
+		geomType, coords = geom.Type, geom.Coordinates
+	}
+
+	switch geomType {
+	case "Polygon":
+		var rings [][][2]float64
+		if err := json.Unmarshal(coords, &rings); err != nil {
+			return nil, err
+		}
+		return []limitPolygon{polygonFromLonLat(rings)}, nil
+	case "MultiPolygon":
+		var polys [][][][2]float64
+		if err := json.Unmarshal(coords, &polys); err != nil {
+			return nil, err
+		}
+		result := make([]limitPolygon, len(polys))
+		for i, p := range polys {
+			result[i] = polygonFromLonLat(p)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("-limit-to: unsupported geometry type %q", geomType)
+	}
+}
+
+// polygonFromLonLat converts a GeoJSON ring set, given as [lon, lat]
+// pairs, into game coordinates.
+func polygonFromLonLat(rings [][][2]float64) limitPolygon {
+	converted := make([][][2]float64, len(rings))
+	for i, ring := range rings {
+		points := make([][2]float64, len(ring))
+		for j, c := range ring {
+			coords := toGameCoords(c[1], c[0], nil)
+			points[j] = [2]float64{coords[0], coords[2]}
+		}
+		converted[i] = points
+	}
+	return limitPolygon{rings: converted}
+}
+
+// pointInLimit reports whether (x, z) falls inside any of the given
+// polygons, honoring holes (a point inside an outer ring but also
+// inside one of its holes is outside the polygon).
+func pointInLimit(polygons []limitPolygon, x, z float64) bool {
+	for _, poly := range polygons {
+		if !pointInRing(poly.rings[0], x, z) {
+			continue
+		}
+		inHole := false
+		for _, hole := range poly.rings[1:] {
+			if pointInRing(hole, x, z) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// pointInRing is a standard even-odd ray-casting point-in-polygon test.
+func pointInRing(ring [][2]float64, x, z float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, zi := ring[i][0], ring[i][1]
+		xj, zj := ring[j][0], ring[j][1]
+		if (zi > z) != (zj > z) {
+			xIntersect := xi + (z-zi)/(zj-zi)*(xj-xi)
+			if x < xIntersect {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// segmentCrossing finds where the segment (p0, p1) crosses the edges
+// of ring, returning the crossing closest to p0 (with its elevation
+// interpolated along the segment) and whether any crossing was found.
+// Used to split a road's point list
+// exactly at the polygon boundary rather than just dropping points.
+func segmentCrossing(ring [][2]float64, p0, p1 []float64) ([]float64, bool) {
+	bestT := 2.0
+	var best []float64
+	found := false
+
+	x0, z0 := p0[0], p0[2]
+	x1, z1 := p1[0], p1[2]
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		ex0, ez0 := ring[j][0], ring[j][1]
+		ex1, ez1 := ring[i][0], ring[i][1]
+
+		t, u, ok := lineIntersection(x0, z0, x1, z1, ex0, ez0, ex1, ez1)
+		if !ok || t < 0 || t > 1 || u < 0 || u > 1 {
+			continue
+		}
+		if t < bestT {
+			bestT = t
+			y := p0[1] + (p1[1]-p0[1])*t
+			best = []float64{x0 + (x1-x0)*t, y, z0 + (z1-z0)*t}
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// lineIntersection solves for the intersection of segment (x0,z0)-(x1,z1)
+// and segment (ex0,ez0)-(ex1,ez1), returning the parametric position t
+// along the first segment and u along the second.
+func lineIntersection(x0, z0, x1, z1, ex0, ez0, ex1, ez1 float64) (t, u float64, ok bool) {
+	dx, dz := x1-x0, z1-z0
+	edx, edz := ex1-ex0, ez1-ez0
+
+	denom := dx*edz - dz*edx
+	if denom == 0 {
+		return 0, 0, false
+	}
+
+	t = ((ex0-x0)*edz - (ez0-z0)*edx) / denom
+	u = ((ex0-x0)*dz - (ez0-z0)*dx) / denom
+	return t, u, true
+}
+
+// nearestCrossing finds the closest polygon-edge crossing (across all
+// rings of all polygons) along the segment from p0 to p1.
+func nearestCrossing(polygons []limitPolygon, p0, p1 []float64) ([]float64, bool) {
+	var best []float64
+	bestDist := -1.0
+	found := false
+
+	for _, poly := range polygons {
+		for _, ring := range poly.rings {
+			cross, ok := segmentCrossing(ring, p0, p1)
+			if !ok {
+				continue
+			}
+			dist := (cross[0]-p0[0])*(cross[0]-p0[0]) + (cross[2]-p0[2])*(cross[2]-p0[2])
+			if !found || dist < bestDist {
+				best = cross
+				bestDist = dist
+				found = true
+			}
+		}
+	}
+
+	return best, found
+}
+
+// clipRoadsToPolygons walks each road's point list and splits it at
+// every polygon-edge crossing, discarding out-of-polygon sub-segments
+// and keeping in-polygon ones as separate Road entries that inherit
+// the parent's tags, mirroring imposm3's limit.Limiter behavior for
+// ways. Road IDs get a "-N" suffix per kept sub-segment. A split is
+// made only where two consecutive points lie on opposite sides of the
+// boundary; a segment whose endpoints are both inside (or both
+// outside) is kept (or dropped) whole even if it crosses the boundary
+// in between.
+func clipRoadsToPolygons(roads []Road, polygons []limitPolygon) []Road {
+	var clipped []Road
+
+	for _, road := range roads {
+		if len(road.Points) == 0 {
+			continue
+		}
+
+		var current [][]float64
+		segIndex := 0
+
+		flush := func() {
+			if len(current) < 2 {
+				current = nil
+				return
+			}
+			sub := road
+			sub.ID = fmt.Sprintf("%s-%d", road.ID, segIndex)
+			sub.Points = current
+			clipped = append(clipped, sub)
+			segIndex++
+			current = nil
+		}
+
+		inside := pointInLimit(polygons, road.Points[0][0], road.Points[0][2])
+		if inside {
+			current = append(current, road.Points[0])
+		}
+
+		for i := 1; i < len(road.Points); i++ {
+			p0, p1 := road.Points[i-1], road.Points[i]
+			nowInside := pointInLimit(polygons, p1[0], p1[2])
+
+			if inside != nowInside {
+				crossing, ok := nearestCrossing(polygons, p0, p1)
+				if ok {
+					current = append(current, crossing)
+				}
+				flush()
+				if nowInside && ok {
+					current = append(current, crossing)
+				}
+			}
+
+			if nowInside {
+				current = append(current, p1)
+			}
+			inside = nowInside
+		}
+
+		flush()
+	}
+
+	return clipped
+}
+
+// filterPOIsToPolygons drops POIs that fall outside every polygon.
+func filterPOIsToPolygons(pois []POI, polygons []limitPolygon) []POI {
+	filtered := make([]POI, 0, len(pois))
+	for _, poi := range pois {
+		if pointInLimit(polygons, poi.X, poi.Z) {
+			filtered = append(filtered, poi)
+		}
+	}
+	return filtered
+}
+
+// filterBoundariesToPolygons drops boundary relations whose ring
+// centroid falls outside every -limit-to polygon, the same centroid
+// test used for relation-derived POIs in relations.go.
+func filterBoundariesToPolygons(boundaries []Boundary, polygons []limitPolygon) []Boundary {
+	filtered := make([]Boundary, 0, len(boundaries))
+	for _, b := range boundaries {
+		cx, cz := ringCentroid(b.Ring)
+		if pointInLimit(polygons, cx, cz) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// tileExtent is the coordinate space each vector tile's geometry is
+// encoded in, per the Mapbox Vector Tile spec default.
+const tileExtent = 4096
+
+// pbWriter is a minimal protobuf wire-format writer. MVT tiles are a
+// small, stable protobuf schema (tile.proto), so hand-encoding it here
+// avoids pulling in a full protobuf runtime for a handful of messages.
+type pbWriter struct {
+	buf []byte
+}
+
+func (w *pbWriter) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf = append(w.buf, tmp[:n]...)
+}
+
+func (w *pbWriter) tag(field, wireType int) {
+	w.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (w *pbWriter) varintField(field int, v uint64) {
+	w.tag(field, 0)
+	w.varint(v)
+}
+
+func (w *pbWriter) bytesField(field int, b []byte) {
+	w.tag(field, 2)
+	w.varint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *pbWriter) stringField(field int, s string) {
+	w.bytesField(field, []byte(s))
+}
+
+func (w *pbWriter) doubleField(field int, v float64) {
+	w.tag(field, 1)
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(v))
+	w.buf = append(w.buf, tmp[:]...)
+}
+
+func (w *pbWriter) packedUint32Field(field int, vals []uint32) {
+	packed := &pbWriter{}
+	for _, v := range vals {
+		packed.varint(uint64(v))
+	}
+	w.bytesField(field, packed.buf)
+}
+
+// mvtGeomType mirrors Tile.GeomType in the vector tile spec.
+type mvtGeomType uint32
+
+const (
+	mvtPoint      mvtGeomType = 1
+	mvtLineString mvtGeomType = 2
+)
+
+type mvtValue struct {
+	kind byte // 's' string, 'f' float64, 'i' int64
+	s    string
+	f    float64
+	i    int64
+}
+
+type mvtFeature struct {
+	geomType mvtGeomType
+	geometry []uint32
+	props    map[string]interface{}
+}
+
+// mvtLayer accumulates features for one named layer (e.g. "roads") and
+// encodes them with the key/value string tables the spec uses to avoid
+// repeating property names and values per feature.
+type mvtLayer struct {
+	name     string
+	features []mvtFeature
+
+	keys   []string
+	keyIdx map[string]int
+	values []mvtValue
+	valIdx map[mvtValue]int
+}
+
+func newMVTLayer(name string) *mvtLayer {
+	return &mvtLayer{
+		name:   name,
+		keyIdx: make(map[string]int),
+		valIdx: make(map[mvtValue]int),
+	}
+}
+
+func (l *mvtLayer) addFeature(geomType mvtGeomType, geometry []uint32, props map[string]interface{}) {
+	l.features = append(l.features, mvtFeature{geomType: geomType, geometry: geometry, props: props})
+}
+
+func (l *mvtLayer) keyIndex(k string) uint32 {
+	if i, ok := l.keyIdx[k]; ok {
+		return uint32(i)
+	}
+	l.keys = append(l.keys, k)
+	i := len(l.keys) - 1
+	l.keyIdx[k] = i
+	return uint32(i)
+}
+
+func (l *mvtLayer) valueIndex(v mvtValue) uint32 {
+	if i, ok := l.valIdx[v]; ok {
+		return uint32(i)
+	}
+	l.values = append(l.values, v)
+	i := len(l.values) - 1
+	l.valIdx[v] = i
+	return uint32(i)
+}
+
+func (l *mvtLayer) encode() []byte {
+	w := &pbWriter{}
+	w.varintField(15, 2) // version
+	w.stringField(1, l.name)
+
+	for _, f := range l.features {
+		var tags []uint32
+		keys := make([]string, 0, len(f.props))
+		for k := range f.props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys) // deterministic output regardless of map iteration order
+
+		for _, k := range keys {
+			var mv mvtValue
+			switch val := f.props[k].(type) {
+			case string:
+				mv = mvtValue{kind: 's', s: val}
+			case int:
+				mv = mvtValue{kind: 'i', i: int64(val)}
+			case float64:
+				mv = mvtValue{kind: 'f', f: val}
+			}
+			tags = append(tags, l.keyIndex(k), l.valueIndex(mv))
+		}
+
+		fw := &pbWriter{}
+		fw.packedUint32Field(2, tags)
+		fw.varintField(3, uint64(f.geomType))
+		fw.packedUint32Field(4, f.geometry)
+		w.bytesField(2, fw.buf)
+	}
+
+	for _, k := range l.keys {
+		w.stringField(3, k)
+	}
+	for _, v := range l.values {
+		vw := &pbWriter{}
+		switch v.kind {
+		case 's':
+			vw.stringField(1, v.s)
+		case 'f':
+			vw.doubleField(3, v.f)
+		case 'i':
+			vw.varintField(4, uint64(v.i))
+		}
+		w.bytesField(4, vw.buf)
+	}
+
+	w.varintField(5, tileExtent)
+	return w.buf
+}
+
+func encodeTile(layers []*mvtLayer) []byte {
+	w := &pbWriter{}
+	for _, l := range layers {
+		if len(l.features) == 0 {
+			continue
+		}
+		w.bytesField(3, l.encode())
+	}
+	return w.buf
+}
+
+func zigzag32(n int32) uint32 {
+	return uint32((n << 1) ^ (n >> 31))
+}
+
+func geomCommand(id, count int) uint32 {
+	return uint32((id & 0x7) | (count << 3))
+}
+
+// encodeLine builds the MVT geometry command stream for an open
+// polyline: a single MoveTo followed by a run of LineTos, each
+// zigzag-delta-encoded from the previous point.
+func encodeLine(points [][2]int32) []uint32 {
+	if len(points) == 0 {
+		return nil
+	}
+
+	cmds := []uint32{geomCommand(1, 1), zigzag32(points[0][0]), zigzag32(points[0][1])}
+	if len(points) == 1 {
+		return cmds
+	}
+
+	cmds = append(cmds, geomCommand(2, len(points)-1))
+	prevX, prevY := points[0][0], points[0][1]
+	for _, p := range points[1:] {
+		cmds = append(cmds, zigzag32(p[0]-prevX), zigzag32(p[1]-prevY))
+		prevX, prevY = p[0], p[1]
+	}
+	return cmds
+}
+
+// encodePoint builds the geometry command stream for a single point.
+func encodePoint(p [2]int32) []uint32 {
+	return []uint32{geomCommand(1, 1), zigzag32(p[0]), zigzag32(p[1])}
+}
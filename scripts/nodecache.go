@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// nodeCacheBucketSize is the number of node IDs spanned by a single
+// on-disk bucket (id >> 10), matching the key used by diskNodeCache.
+const nodeCacheBucketSize = 1024
+
+// NodeCache abstracts how coordinates are kept around between the node
+// pass and the way pass. memNodeCache matches the previous in-process
+// map[int64]*Node behavior; diskNodeCache spills to a LevelDB so
+// country-sized extracts don't have to fit every node in RAM.
+type NodeCache interface {
+	Put(n *Node)
+	Get(id int64) (*Node, bool)
+	Close() error
+}
+
+// memNodeCache is a mutex-guarded map, used when -cache-dir is unset.
+type memNodeCache struct {
+	mu    sync.Mutex
+	nodes map[int64]*Node
+}
+
+func NewMemNodeCache() *memNodeCache {
+	return &memNodeCache{nodes: make(map[int64]*Node)}
+}
+
+func (c *memNodeCache) Put(n *Node) {
+	c.mu.Lock()
+	c.nodes[n.ID] = n
+	c.mu.Unlock()
+}
+
+func (c *memNodeCache) Get(id int64) (*Node, bool) {
+	c.mu.Lock()
+	n, ok := c.nodes[id]
+	c.mu.Unlock()
+	return n, ok
+}
+
+func (c *memNodeCache) Close() error { return nil }
+
+// diskNodeCache is the imposm3-style two-pass node cache: nodes are
+// grouped into ~1024-id buckets (id >> 10), sorted, and delta-encoded
+// as id/lon/lat varints into a single LevelDB value per bucket. A
+// bucket is flushed to disk as soon as it fills; whatever is left
+// over is flushed on Close.
+type diskNodeCache struct {
+	db *leveldb.DB
+
+	mu      sync.Mutex
+	pending map[int64][]*Node
+}
+
+func NewDiskNodeCache(dir string) (*diskNodeCache, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &diskNodeCache{db: db, pending: make(map[int64][]*Node)}, nil
+}
+
+func nodeBucket(id int64) int64 {
+	return id >> 10
+}
+
+func (c *diskNodeCache) Put(n *Node) {
+	bucket := nodeBucket(n.ID)
+
+	c.mu.Lock()
+	c.pending[bucket] = append(c.pending[bucket], n)
+	var flush []*Node
+	if len(c.pending[bucket]) >= nodeCacheBucketSize {
+		flush = c.pending[bucket]
+		delete(c.pending, bucket)
+	}
+	c.mu.Unlock()
+
+	if flush != nil {
+		c.flushBucket(bucket, flush)
+	}
+}
+
+func (c *diskNodeCache) Get(id int64) (*Node, bool) {
+	bucket := nodeBucket(id)
+
+	c.mu.Lock()
+	pending := c.pending[bucket]
+	c.mu.Unlock()
+	for _, n := range pending {
+		if n.ID == id {
+			return n, true
+		}
+	}
+
+	data, err := c.db.Get(bucketKey(bucket), nil)
+	if err != nil {
+		return nil, false
+	}
+
+	nodes, err := decodeNodeBucket(data)
+	if err != nil {
+		return nil, false
+	}
+	for _, n := range nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+func (c *diskNodeCache) Close() error {
+	c.mu.Lock()
+	remaining := c.pending
+	c.pending = make(map[int64][]*Node)
+	c.mu.Unlock()
+
+	for bucket, nodes := range remaining {
+		c.flushBucket(bucket, nodes)
+	}
+
+	return c.db.Close()
+}
+
+func (c *diskNodeCache) flushBucket(bucket int64, nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	if err := c.db.Put(bucketKey(bucket), encodeNodeBucket(nodes), nil); err != nil {
+		fmt.Println("Error writing node cache bucket:", err)
+	}
+}
+
+func bucketKey(bucket int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(bucket))
+	return key
+}
+
+// coordScale converts lat/lon degrees to a fixed-point integer so the
+// delta between consecutive nodes encodes as a small varint.
+const coordScale = 1e7
+
+// eleScale converts an elevation in meters to millimeter-precision
+// fixed-point, plenty for terrain use. Elevation isn't correlated with
+// node ID order the way lon/lat is, so it's stored raw rather than
+// delta-encoded.
+const eleScale = 1e3
+
+func encodeNodeBucket(nodes []*Node) []byte {
+	buf := make([]byte, 0, len(nodes)*10)
+	var lastID, lastLon, lastLat int64
+
+	for _, n := range nodes {
+		lon := int64(math.Round(n.Lon * coordScale))
+		lat := int64(math.Round(n.Lat * coordScale))
+
+		buf = appendVarint(buf, n.ID-lastID)
+		buf = appendVarint(buf, lon-lastLon)
+		buf = appendVarint(buf, lat-lastLat)
+
+		if n.Ele != nil {
+			buf = appendVarint(buf, 1)
+			buf = appendVarint(buf, int64(math.Round(*n.Ele*eleScale)))
+		} else {
+			buf = appendVarint(buf, 0)
+		}
+
+		lastID, lastLon, lastLat = n.ID, lon, lat
+	}
+
+	return buf
+}
+
+func decodeNodeBucket(data []byte) ([]*Node, error) {
+	var nodes []*Node
+	var lastID, lastLon, lastLat int64
+
+	for i := 0; i < len(data); {
+		dID, n := binary.Varint(data[i:])
+		if n <= 0 {
+			return nil, fmt.Errorf("corrupt node cache bucket")
+		}
+		i += n
+
+		dLon, n := binary.Varint(data[i:])
+		if n <= 0 {
+			return nil, fmt.Errorf("corrupt node cache bucket")
+		}
+		i += n
+
+		dLat, n := binary.Varint(data[i:])
+		if n <= 0 {
+			return nil, fmt.Errorf("corrupt node cache bucket")
+		}
+		i += n
+
+		hasEle, n := binary.Varint(data[i:])
+		if n <= 0 {
+			return nil, fmt.Errorf("corrupt node cache bucket")
+		}
+		i += n
+
+		var ele *float64
+		if hasEle != 0 {
+			rawEle, n := binary.Varint(data[i:])
+			if n <= 0 {
+				return nil, fmt.Errorf("corrupt node cache bucket")
+			}
+			i += n
+			v := float64(rawEle) / eleScale
+			ele = &v
+		}
+
+		lastID += dID
+		lastLon += dLon
+		lastLat += dLat
+
+		nodes = append(nodes, &Node{
+			ID:  lastID,
+			Lon: float64(lastLon) / coordScale,
+			Lat: float64(lastLat) / coordScale,
+			Ele: ele,
+		})
+	}
+
+	return nodes, nil
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	tmp := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(tmp, v)
+	return append(buf, tmp[:n]...)
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestEncodeDecodeNodeBucketRoundTrip(t *testing.T) {
+	ele1 := 123.456
+	ele2 := -12.5
+	nodes := []*Node{
+		{ID: 1000, Lat: 7.123456, Lon: 124.654321, Ele: &ele1},
+		{ID: 1001, Lat: 7.1, Lon: 124.6},
+		{ID: 1023, Lat: -7.999999, Lon: -124.000001, Ele: &ele2},
+	}
+
+	encoded := encodeNodeBucket(nodes)
+	decoded, err := decodeNodeBucket(encoded)
+	if err != nil {
+		t.Fatalf("decodeNodeBucket returned error: %v", err)
+	}
+	if len(decoded) != len(nodes) {
+		t.Fatalf("got %d nodes, want %d", len(decoded), len(nodes))
+	}
+
+	for i, want := range nodes {
+		got := decoded[i]
+		if got.ID != want.ID {
+			t.Errorf("node %d: ID = %d, want %d", i, got.ID, want.ID)
+		}
+		if diff := got.Lat - want.Lat; diff > 1e-6 || diff < -1e-6 {
+			t.Errorf("node %d: Lat = %v, want %v", i, got.Lat, want.Lat)
+		}
+		if diff := got.Lon - want.Lon; diff > 1e-6 || diff < -1e-6 {
+			t.Errorf("node %d: Lon = %v, want %v", i, got.Lon, want.Lon)
+		}
+
+		switch {
+		case want.Ele == nil && got.Ele != nil:
+			t.Errorf("node %d: Ele = %v, want nil", i, *got.Ele)
+		case want.Ele != nil && got.Ele == nil:
+			t.Errorf("node %d: Ele = nil, want %v", i, *want.Ele)
+		case want.Ele != nil && got.Ele != nil:
+			if diff := *got.Ele - *want.Ele; diff > 1e-3 || diff < -1e-3 {
+				t.Errorf("node %d: Ele = %v, want %v", i, *got.Ele, *want.Ele)
+			}
+		}
+	}
+}
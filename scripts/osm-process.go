@@ -54,9 +54,10 @@ var config = struct {
 
 // OSM XML structures
 type OSM struct {
-	XMLName xml.Name  `xml:"osm"`
-	Nodes   []OSMNode `xml:"node"`
-	Ways    []OSMWay  `xml:"way"`
+	XMLName   xml.Name      `xml:"osm"`
+	Nodes     []OSMNode     `xml:"node"`
+	Ways      []OSMWay      `xml:"way"`
+	Relations []OSMRelation `xml:"relation"`
 }
 
 type OSMNode struct {
@@ -76,6 +77,18 @@ type OSMNd struct {
 	Ref int64 `xml:"ref,attr"`
 }
 
+type OSMRelation struct {
+	ID      int64       `xml:"id,attr"`
+	Members []OSMMember `xml:"member"`
+	Tags    []OSMTag    `xml:"tag"`
+}
+
+type OSMMember struct {
+	Type string `xml:"type,attr"`
+	Ref  int64  `xml:"ref,attr"`
+	Role string `xml:"role,attr"`
+}
+
 type OSMTag struct {
 	K string `xml:"k,attr"`
 	V string `xml:"v,attr"`
@@ -149,10 +162,58 @@ type POIsOutput struct {
 	POIs []POI `json:"pois"`
 }
 
+type Boundary struct {
+	ID    string      `json:"id"`
+	Level string      `json:"level"`
+	Name  *string     `json:"name"`
+	Ring  [][]float64 `json:"ring"`
+}
+
+type BoundariesOutput struct {
+	Origin struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"origin"`
+	Boundaries []Boundary `json:"boundaries"`
+}
+
 func main() {
 	inputFile := flag.String("input", "", "Input OSM file path")
+	srtmDir := flag.String("srtm", "", "Directory of SRTM .hgt/.hgt.gz tiles for terrain elevation")
+	cacheDir := flag.String("cache-dir", "", "Directory for an on-disk node coordinate cache (required for country-sized extracts)")
+	pmtilesPath := flag.String("pmtiles", "", "Write a PMTiles archive of roads/POIs for browser-based preview")
+	pmtilesMaxZoom := flag.Int("pmtiles-max-zoom", 14, "Maximum zoom level to generate in the PMTiles archive")
+	limitTo := flag.String("limit-to", "", "GeoJSON polygon/multipolygon to clip the output to, e.g. a single province")
 	flag.Parse()
 
+	var limitPolygons []limitPolygon
+	if *limitTo != "" {
+		var err error
+		limitPolygons, err = loadLimitPolygon(*limitTo)
+		if err != nil {
+			fmt.Println("Error loading -limit-to polygon:", err)
+			os.Exit(1)
+		}
+	}
+
+	var srtm *SRTM
+	if *srtmDir != "" {
+		srtm = NewSRTM(*srtmDir)
+	}
+
+	var nodeCache NodeCache
+	if *cacheDir != "" {
+		diskCache, err := NewDiskNodeCache(*cacheDir)
+		if err != nil {
+			fmt.Println("Error opening node cache:", err)
+			os.Exit(1)
+		}
+		nodeCache = diskCache
+	} else {
+		nodeCache = NewMemNodeCache()
+	}
+	defer nodeCache.Close()
+
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println("Mindanao Truck Simulator - OSM Processing Tool (Go)")
 	fmt.Println(strings.Repeat("=", 60))
@@ -185,37 +246,77 @@ func main() {
 
 	startTime := time.Now()
 
-	// Parse OSM file
-	fmt.Println("\nParsing OSM file...")
-	osm, err := parseOSMFile(filePath)
-	if err != nil {
-		fmt.Println("Error parsing OSM:", err)
-		os.Exit(1)
+	// PBF inputs are decoded block-by-block so we never materialize the
+	// whole file in memory; plain XML is still small enough to slurp.
+	var nodeChunks <-chan []OSMNode
+	var wayChunks <-chan []OSMWay
+	var relChunks <-chan []OSMRelation
+
+	if isPBF(filePath) {
+		fmt.Println("\nStreaming PBF file...")
+		var err error
+		nodeChunks, wayChunks, relChunks, err = streamPBFFile(filePath, numCPU)
+		if err != nil {
+			fmt.Println("Error opening PBF:", err)
+			os.Exit(1)
+		}
+	} else {
+		fmt.Println("\nParsing OSM file...")
+		osm, err := parseOSMFile(filePath)
+		if err != nil {
+			fmt.Println("Error parsing OSM:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("  Parsed %d nodes, %d ways, %d relations in %.1fs\n",
+			len(osm.Nodes), len(osm.Ways), len(osm.Relations), time.Since(startTime).Seconds())
+		nodeChunks = chunkNodes(osm.Nodes, numCPU)
+		wayChunks = chunkWays(osm.Ways, numCPU)
+		relChunks = chunkRelations(osm.Relations, numCPU)
 	}
-	fmt.Printf("  Parsed %d nodes, %d ways in %.1fs\n", len(osm.Nodes), len(osm.Ways), time.Since(startTime).Seconds())
 
 	// Process nodes in parallel
 	fmt.Println("\nProcessing nodes...")
 	nodeStart := time.Now()
-	nodes, pois, elevationPoints := processNodesParallel(osm.Nodes, numCPU)
-	fmt.Printf("  Nodes: %d | POIs: %d | Elevation points: %d (%.1fs)\n",
-		len(nodes), len(pois), len(elevationPoints), time.Since(nodeStart).Seconds())
+	pois, elevationPoints := processNodesParallel(nodeChunks, numCPU, srtm, nodeCache)
+	fmt.Printf("  POIs: %d | Elevation points: %d (%.1fs)\n",
+		len(pois), len(elevationPoints), time.Since(nodeStart).Seconds())
+	elevIndex := NewElevationIndex(elevationPoints)
 
 	// Process ways in parallel
 	fmt.Println("\nProcessing roads...")
 	roadStart := time.Now()
-	roads, totalPoints, pointsWithElevation := processWaysParallel(osm.Ways, nodes, elevationPoints, numCPU)
+	roads, totalPoints, pointsWithElevation, wayGeoms := processWaysParallel(wayChunks, nodeCache, elevIndex, srtm, numCPU)
 	fmt.Printf("  Roads: %d | Points: %d (%.1fs)\n", len(roads), totalPoints, time.Since(roadStart).Seconds())
 	fmt.Printf("  Points with elevation: %d / %d\n", pointsWithElevation, totalPoints)
 
+	// Clip to -limit-to polygon, if given, before computing bounds so
+	// they reflect the clipped region rather than the full extract.
+	if limitPolygons != nil {
+		preClip := len(roads)
+		roads = clipRoadsToPolygons(roads, limitPolygons)
+		fmt.Printf("  Clipped to -limit-to polygon: %d roads -> %d\n", preClip, len(roads))
+		totalPoints, pointsWithElevation = countRoadPoints(roads)
+	}
+
 	// Calculate bounds
 	bounds := calculateBounds(roads)
 	if bounds.MinY != 0 || bounds.MaxY != 0 {
 		fmt.Printf("  Elevation range: %.1fm to %.1fm\n", bounds.MinY, bounds.MaxY)
 	}
 
+	// Process relations: administrative boundaries and multipolygon POIs
+	fmt.Println("\nProcessing relations...")
+	relStart := time.Now()
+	relPOIs, boundaries := processRelationsParallel(relChunks, wayGeoms, elevIndex, srtm, numCPU)
+	pois = append(pois, relPOIs...)
+	fmt.Printf("  Boundary POIs: %d | Boundaries: %d (%.1fs)\n", len(relPOIs), len(boundaries), time.Since(relStart).Seconds())
+
 	// Process POIs with game coordinates
 	processedPOIs := processPOIs(pois, elevationPoints)
+	if limitPolygons != nil {
+		processedPOIs = filterPOIsToPolygons(processedPOIs, limitPolygons)
+		boundaries = filterBoundariesToPolygons(boundaries, limitPolygons)
+	}
 
 	// Prepare output
 	meta := Meta{
@@ -243,6 +344,20 @@ func main() {
 	poisOutput.Origin.Lon = config.Origin.Lon
 	saveJSON(filepath.Join(outputDir, "pois.json"), poisOutput)
 
+	// Save boundaries.json
+	boundariesOutput := BoundariesOutput{Boundaries: boundaries}
+	boundariesOutput.Origin.Lat = config.Origin.Lat
+	boundariesOutput.Origin.Lon = config.Origin.Lon
+	saveJSON(filepath.Join(outputDir, "boundaries.json"), boundariesOutput)
+
+	// Save pmtiles preview archive
+	if *pmtilesPath != "" {
+		fmt.Println("\nBuilding PMTiles preview...")
+		if err := writePMTiles(*pmtilesPath, roads, processedPOIs, *pmtilesMaxZoom); err != nil {
+			fmt.Println("Error writing PMTiles:", err)
+		}
+	}
+
 	elapsed := time.Since(startTime).Seconds()
 	fmt.Println("\nSaved to:", outputDir)
 	fmt.Printf("Processing complete in %.1fs!\n", elapsed)
@@ -262,7 +377,7 @@ func findLatestOSMFile() string {
 
 	var osmFiles []fileWithTime
 	for _, f := range files {
-		if strings.HasSuffix(f.Name(), ".osm") {
+		if strings.HasSuffix(f.Name(), ".osm") || isPBF(f.Name()) {
 			info, err := f.Info()
 			if err == nil {
 				osmFiles = append(osmFiles, fileWithTime{
@@ -284,6 +399,10 @@ func findLatestOSMFile() string {
 	return osmFiles[0].path
 }
 
+func isPBF(filePath string) bool {
+	return strings.HasSuffix(filePath, ".osm.pbf") || strings.HasSuffix(filePath, ".pbf")
+}
+
 func parseOSMFile(filePath string) (*OSM, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -306,100 +425,150 @@ func parseOSMFile(filePath string) (*OSM, error) {
 	return &osm, nil
 }
 
-func processNodesParallel(osmNodes []OSMNode, numWorkers int) (map[int64]*Node, []POI, []ElevationPoint) {
-	nodes := make(map[int64]*Node)
-	var nodesMutex sync.Mutex
+// chunkNodes splits an in-memory node slice into per-worker chunks and
+// feeds them through a channel so callers can treat XML and PBF input
+// uniformly.
+func chunkNodes(osmNodes []OSMNode, numWorkers int) <-chan []OSMNode {
+	out := make(chan []OSMNode, numWorkers)
+	go func() {
+		defer close(out)
+		chunkSize := (len(osmNodes) + numWorkers - 1) / numWorkers
+		if chunkSize == 0 {
+			return
+		}
+		for start := 0; start < len(osmNodes); start += chunkSize {
+			end := start + chunkSize
+			if end > len(osmNodes) {
+				end = len(osmNodes)
+			}
+			out <- osmNodes[start:end]
+		}
+	}()
+	return out
+}
+
+// chunkWays is the Way equivalent of chunkNodes.
+func chunkWays(osmWays []OSMWay, numWorkers int) <-chan []OSMWay {
+	out := make(chan []OSMWay, numWorkers)
+	go func() {
+		defer close(out)
+		chunkSize := (len(osmWays) + numWorkers - 1) / numWorkers
+		if chunkSize == 0 {
+			return
+		}
+		for start := 0; start < len(osmWays); start += chunkSize {
+			end := start + chunkSize
+			if end > len(osmWays) {
+				end = len(osmWays)
+			}
+			out <- osmWays[start:end]
+		}
+	}()
+	return out
+}
+
+// chunkRelations is the Relation equivalent of chunkNodes.
+func chunkRelations(osmRelations []OSMRelation, numWorkers int) <-chan []OSMRelation {
+	out := make(chan []OSMRelation, numWorkers)
+	go func() {
+		defer close(out)
+		chunkSize := (len(osmRelations) + numWorkers - 1) / numWorkers
+		if chunkSize == 0 {
+			return
+		}
+		for start := 0; start < len(osmRelations); start += chunkSize {
+			end := start + chunkSize
+			if end > len(osmRelations) {
+				end = len(osmRelations)
+			}
+			out <- osmRelations[start:end]
+		}
+	}()
+	return out
+}
 
+func processNodesParallel(nodeChunks <-chan []OSMNode, numWorkers int, srtm *SRTM, cache NodeCache) ([]POI, []ElevationPoint) {
 	var pois []POI
 	var poisMutex sync.Mutex
 
 	var elevationPoints []ElevationPoint
 	var elevMutex sync.Mutex
 
-	chunkSize := (len(osmNodes) + numWorkers - 1) / numWorkers
 	var wg sync.WaitGroup
 
 	for i := 0; i < numWorkers; i++ {
-		start := i * chunkSize
-		end := start + chunkSize
-		if end > len(osmNodes) {
-			end = len(osmNodes)
-		}
-		if start >= len(osmNodes) {
-			break
-		}
-
 		wg.Add(1)
-		go func(chunk []OSMNode) {
+		go func() {
 			defer wg.Done()
 
-			localNodes := make(map[int64]*Node)
 			var localPOIs []POI
 			var localElev []ElevationPoint
 
-			for _, n := range chunk {
-				node := &Node{
-					ID:  n.ID,
-					Lat: n.Lat,
-					Lon: n.Lon,
-				}
+			for chunk := range nodeChunks {
+				for _, n := range chunk {
+					node := &Node{
+						ID:  n.ID,
+						Lat: n.Lat,
+						Lon: n.Lon,
+					}
 
-				var name *string
-				var place, amenity string
+					var name *string
+					var place, amenity string
+
+					for _, tag := range n.Tags {
+						switch tag.K {
+						case "ele":
+							if ele, err := strconv.ParseFloat(tag.V, 64); err == nil {
+								node.Ele = &ele
+								localElev = append(localElev, ElevationPoint{Lat: n.Lat, Lon: n.Lon, Ele: ele})
+							}
+						case "name":
+							nameCopy := tag.V
+							name = &nameCopy
+						case "place":
+							place = tag.V
+						case "amenity":
+							amenity = tag.V
+						}
+					}
 
-				for _, tag := range n.Tags {
-					switch tag.K {
-					case "ele":
-						if ele, err := strconv.ParseFloat(tag.V, 64); err == nil {
+					if node.Ele == nil && srtm != nil {
+						if ele, ok := srtm.Elevation(n.Lat, n.Lon); ok {
 							node.Ele = &ele
-							localElev = append(localElev, ElevationPoint{Lat: n.Lat, Lon: n.Lon, Ele: ele})
 						}
-					case "name":
-						nameCopy := tag.V
-						name = &nameCopy
-					case "place":
-						place = tag.V
-					case "amenity":
-						amenity = tag.V
 					}
-				}
 
-				localNodes[n.ID] = node
-
-				if place == "city" || place == "town" {
-					coords := toGameCoords(n.Lat, n.Lon, node.Ele)
-					localPOIs = append(localPOIs, POI{
-						ID:   strconv.FormatInt(n.ID, 10),
-						Type: place,
-						Name: name,
-						X:    coords[0],
-						Y:    coords[1],
-						Z:    coords[2],
-					})
-				} else if amenity == "fuel" {
-					coords := toGameCoords(n.Lat, n.Lon, node.Ele)
-					defaultName := "Gas Station"
-					if name == nil {
-						name = &defaultName
+					cache.Put(node)
+
+					if place == "city" || place == "town" {
+						coords := toGameCoords(n.Lat, n.Lon, node.Ele)
+						localPOIs = append(localPOIs, POI{
+							ID:   strconv.FormatInt(n.ID, 10),
+							Type: place,
+							Name: name,
+							X:    coords[0],
+							Y:    coords[1],
+							Z:    coords[2],
+						})
+					} else if amenity == "fuel" {
+						coords := toGameCoords(n.Lat, n.Lon, node.Ele)
+						defaultName := "Gas Station"
+						if name == nil {
+							name = &defaultName
+						}
+						localPOIs = append(localPOIs, POI{
+							ID:   strconv.FormatInt(n.ID, 10),
+							Type: "fuel",
+							Name: name,
+							X:    coords[0],
+							Y:    coords[1],
+							Z:    coords[2],
+						})
 					}
-					localPOIs = append(localPOIs, POI{
-						ID:   strconv.FormatInt(n.ID, 10),
-						Type: "fuel",
-						Name: name,
-						X:    coords[0],
-						Y:    coords[1],
-						Z:    coords[2],
-					})
 				}
 			}
 
 			// Merge results
-			nodesMutex.Lock()
-			for k, v := range localNodes {
-				nodes[k] = v
-			}
-			nodesMutex.Unlock()
-
 			poisMutex.Lock()
 			pois = append(pois, localPOIs...)
 			poisMutex.Unlock()
@@ -407,122 +576,139 @@ func processNodesParallel(osmNodes []OSMNode, numWorkers int) (map[int64]*Node,
 			elevMutex.Lock()
 			elevationPoints = append(elevationPoints, localElev...)
 			elevMutex.Unlock()
-		}(osmNodes[start:end])
+		}()
 	}
 
 	wg.Wait()
-	return nodes, pois, elevationPoints
+	return pois, elevationPoints
 }
 
-func processWaysParallel(osmWays []OSMWay, nodes map[int64]*Node, elevationPoints []ElevationPoint, numWorkers int) ([]Road, int, int) {
+// resolveWayPoints converts a way's node refs into game-coord points,
+// filling in elevation from the node tag, SRTM, or the OSM-tag IDW
+// index (in that priority order) when a node has none of its own. It
+// also returns how many of those points ended up with a non-zero
+// elevation, for the processing summary.
+func resolveWayPoints(way OSMWay, cache NodeCache, elevIndex *ElevationIndex, srtm *SRTM) ([][]float64, int) {
+	var points [][]float64
+	withElevation := 0
+
+	for _, nd := range way.NodeRefs {
+		node, exists := cache.Get(nd.Ref)
+		if !exists {
+			continue
+		}
+
+		ele := node.Ele
+		if ele == nil && srtm != nil {
+			if srtmEle, ok := srtm.Elevation(node.Lat, node.Lon); ok {
+				ele = &srtmEle
+			}
+		}
+		if ele == nil && elevIndex != nil {
+			interpolated := elevIndex.Lookup(node.Lat, node.Lon)
+			ele = &interpolated
+		}
+
+		if ele != nil && *ele != 0 {
+			withElevation++
+		}
+
+		points = append(points, toGameCoords(node.Lat, node.Lon, ele))
+	}
+
+	return points, withElevation
+}
+
+func processWaysParallel(wayChunks <-chan []OSMWay, cache NodeCache, elevIndex *ElevationIndex, srtm *SRTM, numWorkers int) ([]Road, int, int, map[int64][][]float64) {
 	var roads []Road
 	var roadsMutex sync.Mutex
 	var totalPoints, pointsWithElevation int
 	var countMutex sync.Mutex
 
-	chunkSize := (len(osmWays) + numWorkers - 1) / numWorkers
+	wayGeoms := make(map[int64][][]float64)
+	var wayGeomsMutex sync.Mutex
+
 	var wg sync.WaitGroup
 
 	for i := 0; i < numWorkers; i++ {
-		start := i * chunkSize
-		end := start + chunkSize
-		if end > len(osmWays) {
-			end = len(osmWays)
-		}
-		if start >= len(osmWays) {
-			break
-		}
-
 		wg.Add(1)
-		go func(chunk []OSMWay) {
+		go func() {
 			defer wg.Done()
 
 			var localRoads []Road
 			localTotalPoints := 0
 			localPointsWithElev := 0
+			localWayGeoms := make(map[int64][][]float64)
 
-			for _, way := range chunk {
-				tags := make(map[string]string)
-				for _, tag := range way.Tags {
-					tags[tag.K] = tag.V
-				}
+			for chunk := range wayChunks {
+				for _, way := range chunk {
+					tags := make(map[string]string)
+					for _, tag := range way.Tags {
+						tags[tag.K] = tag.V
+					}
 
-				highway, ok := tags["highway"]
-				if !ok {
-					continue
-				}
+					points, withElev := resolveWayPoints(way, cache, elevIndex, srtm)
+					if len(points) >= 2 {
+						localWayGeoms[way.ID] = points
+					}
 
-				var points [][]float64
-				for _, nd := range way.NodeRefs {
-					node, exists := nodes[nd.Ref]
-					if !exists {
+					highway, ok := tags["highway"]
+					if !ok {
 						continue
 					}
 
-					ele := node.Ele
-					if ele == nil && len(elevationPoints) > 0 {
-						interpolated := interpolateElevation(node.Lat, node.Lon, elevationPoints)
-						ele = &interpolated
+					if len(points) < 2 {
+						continue
 					}
+					localPointsWithElev += withElev
 
-					if ele != nil && *ele != 0 {
-						localPointsWithElev++
+					width := config.RoadWidths[highway]
+					if width == 0 {
+						width = config.RoadWidths["default"]
 					}
 
-					coords := toGameCoords(node.Lat, node.Lon, ele)
-					points = append(points, coords)
-				}
-
-				if len(points) < 2 {
-					continue
-				}
+					speedLimit := config.SpeedLimits[highway]
+					if speedLimit == 0 {
+						speedLimit = config.SpeedLimits["default"]
+					}
+					if maxspeed, ok := tags["maxspeed"]; ok {
+						if ms, err := strconv.Atoi(strings.TrimSuffix(maxspeed, " km/h")); err == nil {
+							speedLimit = ms
+						}
+					}
 
-				width := config.RoadWidths[highway]
-				if width == 0 {
-					width = config.RoadWidths["default"]
-				}
+					lanes := 2
+					if lanesStr, ok := tags["lanes"]; ok {
+						if l, err := strconv.Atoi(lanesStr); err == nil {
+							lanes = l
+						}
+					}
 
-				speedLimit := config.SpeedLimits[highway]
-				if speedLimit == 0 {
-					speedLimit = config.SpeedLimits["default"]
-				}
-				if maxspeed, ok := tags["maxspeed"]; ok {
-					if ms, err := strconv.Atoi(strings.TrimSuffix(maxspeed, " km/h")); err == nil {
-						speedLimit = ms
+					surface := tags["surface"]
+					if surface == "" {
+						surface = "asphalt"
 					}
-				}
 
-				lanes := 2
-				if lanesStr, ok := tags["lanes"]; ok {
-					if l, err := strconv.Atoi(lanesStr); err == nil {
-						lanes = l
+					var name *string
+					if n, ok := tags["name"]; ok {
+						name = &n
+					} else if r, ok := tags["ref"]; ok {
+						name = &r
 					}
-				}
 
-				surface := tags["surface"]
-				if surface == "" {
-					surface = "asphalt"
-				}
+					localRoads = append(localRoads, Road{
+						ID:         strconv.FormatInt(way.ID, 10),
+						Type:       highway,
+						Name:       name,
+						Width:      width,
+						SpeedLimit: speedLimit,
+						Lanes:      lanes,
+						Surface:    surface,
+						Points:     points,
+					})
 
-				var name *string
-				if n, ok := tags["name"]; ok {
-					name = &n
-				} else if r, ok := tags["ref"]; ok {
-					name = &r
+					localTotalPoints += len(points)
 				}
-
-				localRoads = append(localRoads, Road{
-					ID:         strconv.FormatInt(way.ID, 10),
-					Type:       highway,
-					Name:       name,
-					Width:      width,
-					SpeedLimit: speedLimit,
-					Lanes:      lanes,
-					Surface:    surface,
-					Points:     points,
-				})
-
-				localTotalPoints += len(points)
 			}
 
 			roadsMutex.Lock()
@@ -533,11 +719,17 @@ func processWaysParallel(osmWays []OSMWay, nodes map[int64]*Node, elevationPoint
 			totalPoints += localTotalPoints
 			pointsWithElevation += localPointsWithElev
 			countMutex.Unlock()
-		}(osmWays[start:end])
+
+			wayGeomsMutex.Lock()
+			for id, points := range localWayGeoms {
+				wayGeoms[id] = points
+			}
+			wayGeomsMutex.Unlock()
+		}()
 	}
 
 	wg.Wait()
-	return roads, totalPoints, pointsWithElevation
+	return roads, totalPoints, pointsWithElevation, wayGeoms
 }
 
 func toGameCoords(lat, lon float64, ele *float64) []float64 {
@@ -550,38 +742,13 @@ func toGameCoords(lat, lon float64, ele *float64) []float64 {
 	return []float64{x, y, z}
 }
 
-func interpolateElevation(lat, lon float64, elevationPoints []ElevationPoint) float64 {
-	if len(elevationPoints) == 0 {
-		return 0
-	}
-
-	maxDistance := 0.1
-	weightSum := 0.0
-	valueSum := 0.0
-	foundNearby := false
-
-	for _, ep := range elevationPoints {
-		dLat := lat - ep.Lat
-		dLon := lon - ep.Lon
-		distance := math.Sqrt(dLat*dLat + dLon*dLon)
-
-		if distance < 0.0001 {
-			return ep.Ele
-		}
-
-		if distance < maxDistance {
-			foundNearby = true
-			weight := 1 / (distance * distance)
-			weightSum += weight
-			valueSum += weight * ep.Ele
-		}
-	}
-
-	if foundNearby && weightSum > 0 {
-		return valueSum / weightSum
-	}
-
-	return 0
+// fromGameCoords inverts toGameCoords' horizontal projection, used
+// wherever a game-coord point needs to be resolved back to lat/lon
+// (e.g. looking up terrain elevation at a polygon centroid).
+func fromGameCoords(x, z float64) (lat, lon float64) {
+	lon = x/config.MetersPerDegreeLon + config.Origin.Lon
+	lat = config.Origin.Lat - z/config.MetersPerDegreeLat
+	return lat, lon
 }
 
 func calculateBounds(roads []Road) Bounds {
@@ -613,6 +780,22 @@ func calculateBounds(roads []Road) Bounds {
 	return bounds
 }
 
+// countRoadPoints re-derives total and elevated point counts from a
+// road slice, the same way processWaysParallel counts them as it
+// resolves each way's points. Used to refresh meta after -limit-to
+// clipping changes which points actually ship in roads.json.
+func countRoadPoints(roads []Road) (total int, withElevation int) {
+	for _, road := range roads {
+		for _, point := range road.Points {
+			total++
+			if point[1] != 0 {
+				withElevation++
+			}
+		}
+	}
+	return total, withElevation
+}
+
 func processPOIs(pois []POI, elevationPoints []ElevationPoint) []POI {
 	// POIs are already processed with coordinates in processNodesParallel
 	return pois
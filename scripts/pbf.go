@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/qedus/osmpbf"
+)
+
+// pbfBatchSize controls how many decoded elements are grouped into a
+// single chunk before being handed to the worker pool. Small enough to
+// keep memory bounded, large enough to amortize channel overhead.
+const pbfBatchSize = 2000
+
+// streamPBFFile decodes a .osm.pbf file block-by-block and streams
+// Nodes, Ways and Relations out through channels, instead of
+// unmarshaling the whole file into memory like parseOSMFile does for
+// XML. PBF blocks are ordered nodes-then-ways-then-relations, so the
+// node channel is closed as soon as the first Way is seen (and the way
+// channel as soon as the first Relation is seen), letting each stage's
+// worker pool finish while the next element type is still decoding.
+func streamPBFFile(filePath string, numWorkers int) (<-chan []OSMNode, <-chan []OSMWay, <-chan []OSMRelation, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	decoder := osmpbf.NewDecoder(f)
+	decoder.SetBufferSize(osmpbf.MaxBlobSize)
+	if err := decoder.Start(numWorkers); err != nil {
+		f.Close()
+		return nil, nil, nil, err
+	}
+
+	nodeChunks := make(chan []OSMNode, numWorkers)
+	wayChunks := make(chan []OSMWay, numWorkers)
+	relChunks := make(chan []OSMRelation, numWorkers)
+
+	go func() {
+		defer f.Close()
+
+		var nodeBuf []OSMNode
+		var wayBuf []OSMWay
+		var relBuf []OSMRelation
+		nodesClosed := false
+		waysClosed := false
+
+		flushNodes := func() {
+			if len(nodeBuf) > 0 {
+				nodeChunks <- nodeBuf
+				nodeBuf = nil
+			}
+		}
+		flushWays := func() {
+			if len(wayBuf) > 0 {
+				wayChunks <- wayBuf
+				wayBuf = nil
+			}
+		}
+		flushRels := func() {
+			if len(relBuf) > 0 {
+				relChunks <- relBuf
+				relBuf = nil
+			}
+		}
+		closeNodes := func() {
+			if !nodesClosed {
+				flushNodes()
+				close(nodeChunks)
+				nodesClosed = true
+			}
+		}
+		closeWays := func() {
+			closeNodes()
+			if !waysClosed {
+				flushWays()
+				close(wayChunks)
+				waysClosed = true
+			}
+		}
+
+		for {
+			v, err := decoder.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				fmt.Println("Error decoding PBF:", err)
+				break
+			}
+
+			switch t := v.(type) {
+			case *osmpbf.Node:
+				nodeBuf = append(nodeBuf, nodeFromPBF(t))
+				if len(nodeBuf) >= pbfBatchSize {
+					flushNodes()
+				}
+			case *osmpbf.Way:
+				closeNodes()
+				wayBuf = append(wayBuf, wayFromPBF(t))
+				if len(wayBuf) >= pbfBatchSize {
+					flushWays()
+				}
+			case *osmpbf.Relation:
+				closeWays()
+				relBuf = append(relBuf, relationFromPBF(t))
+				if len(relBuf) >= pbfBatchSize {
+					flushRels()
+				}
+			}
+		}
+
+		closeWays()
+		flushRels()
+		close(relChunks)
+	}()
+
+	return nodeChunks, wayChunks, relChunks, nil
+}
+
+func nodeFromPBF(n *osmpbf.Node) OSMNode {
+	tags := make([]OSMTag, 0, len(n.Tags))
+	for k, v := range n.Tags {
+		tags = append(tags, OSMTag{K: k, V: v})
+	}
+	return OSMNode{ID: n.ID, Lat: n.Lat, Lon: n.Lon, Tags: tags}
+}
+
+func wayFromPBF(w *osmpbf.Way) OSMWay {
+	tags := make([]OSMTag, 0, len(w.Tags))
+	for k, v := range w.Tags {
+		tags = append(tags, OSMTag{K: k, V: v})
+	}
+	refs := make([]OSMNd, 0, len(w.NodeIDs))
+	for _, id := range w.NodeIDs {
+		refs = append(refs, OSMNd{Ref: id})
+	}
+	return OSMWay{ID: w.ID, NodeRefs: refs, Tags: tags}
+}
+
+func relationFromPBF(r *osmpbf.Relation) OSMRelation {
+	tags := make([]OSMTag, 0, len(r.Tags))
+	for k, v := range r.Tags {
+		tags = append(tags, OSMTag{K: k, V: v})
+	}
+	members := make([]OSMMember, 0, len(r.Members))
+	for _, m := range r.Members {
+		members = append(members, OSMMember{Type: memberTypeName(m.Type), Ref: m.ID, Role: m.Role})
+	}
+	return OSMRelation{ID: r.ID, Members: members, Tags: tags}
+}
+
+func memberTypeName(t osmpbf.MemberType) string {
+	switch t {
+	case osmpbf.WayType:
+		return "way"
+	case osmpbf.RelationType:
+		return "relation"
+	default:
+		return "node"
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// pmtilesHeaderSize is the fixed header length defined by the PMTiles
+// v3 spec; the root directory immediately follows it.
+const pmtilesHeaderSize = 127
+
+const (
+	pmCompressionGzip = 2
+	pmTileTypeMVT     = 1
+)
+
+type tileKey struct {
+	z, x, y uint32
+}
+
+type tileBuilder struct {
+	roads *mvtLayer
+	pois  *mvtLayer
+}
+
+// writePMTiles renders roads and POIs into a zoomed Web Mercator tile
+// pyramid, encodes each tile as a Mapbox Vector Tile, and packs the
+// result into a single PMTiles v3 archive so the map can be previewed
+// without standing up a tile server.
+func writePMTiles(path string, roads []Road, pois []POI, maxZoom int) error {
+	tiles := make(map[tileKey]*tileBuilder)
+
+	tileFor := func(z, x, y uint32) *tileBuilder {
+		k := tileKey{z, x, y}
+		t, ok := tiles[k]
+		if !ok {
+			t = &tileBuilder{roads: newMVTLayer("roads"), pois: newMVTLayer("pois")}
+			tiles[k] = t
+		}
+		return t
+	}
+
+	for z := 0; z <= maxZoom; z++ {
+		tolerance := simplifyTolerance(z)
+
+		for _, road := range roads {
+			lonLat := roadLonLat(road)
+			simplified := simplify(lonLat, tolerance)
+			if len(simplified) < 2 {
+				continue
+			}
+			addLineToTiles(simplified, z, tileFor, road, func(t *tileBuilder, geom []uint32) {
+				t.roads.addFeature(mvtLineString, geom, map[string]interface{}{
+					"type":       road.Type,
+					"name":       derefOr(road.Name, ""),
+					"lanes":      road.Lanes,
+					"speedLimit": road.SpeedLimit,
+				})
+			})
+		}
+
+		for _, poi := range pois {
+			lat, lon := fromGameCoords(poi.X, poi.Z)
+			tx, ty := lonLatTile(lon, lat, z)
+			local := tileLocalCoord(lon, lat, z, tx, ty)
+			tileFor(uint32(z), tx, ty).pois.addFeature(mvtPoint, encodePoint(local), map[string]interface{}{
+				"type": poi.Type,
+				"name": derefOr(poi.Name, ""),
+			})
+		}
+	}
+
+	return assemblePMTiles(path, tiles, maxZoom)
+}
+
+func derefOr(s *string, fallback string) string {
+	if s == nil {
+		return fallback
+	}
+	return *s
+}
+
+func roadLonLat(road Road) [][2]float64 {
+	points := make([][2]float64, len(road.Points))
+	for i, p := range road.Points {
+		lat, lon := fromGameCoords(p[0], p[2])
+		points[i] = [2]float64{lon, lat}
+	}
+	return points
+}
+
+// simplifyTolerance widens the Douglas-Peucker tolerance at low zoom
+// levels (coarse, cheap geometry) and narrows it near maxZoom (closer
+// to the source data): four units of the tileExtent grid, in degrees.
+func simplifyTolerance(zoom int) float64 {
+	return 360.0 / (math.Exp2(float64(zoom)) * tileExtent) * 4
+}
+
+func lonLatTile(lon, lat float64, zoom int) (uint32, uint32) {
+	fx, fy := lonLatToTileFrac(lon, lat, zoom)
+	return uint32(fx), uint32(fy)
+}
+
+func lonLatToTileFrac(lon, lat float64, zoom int) (float64, float64) {
+	n := math.Exp2(float64(zoom))
+	x := (lon + 180) / 360 * n
+	latRad := lat * math.Pi / 180
+	y := (1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n
+	return x, y
+}
+
+func tileLocalCoord(lon, lat float64, zoom int, tx, ty uint32) [2]int32 {
+	fx, fy := lonLatToTileFrac(lon, lat, zoom)
+	px := (fx - float64(tx)) * tileExtent
+	py := (fy - float64(ty)) * tileExtent
+	return [2]int32{int32(math.Round(px)), int32(math.Round(py))}
+}
+
+// addLineToTiles walks a simplified line in tile-fraction space and
+// splits it into one sub-line per tile it actually crosses, clipping
+// at each grid-line crossing. Earlier this stamped the line's whole,
+// unclipped point list into every tile in its bounding-box rectangle,
+// which is a combinatorial blow-up for any line spanning more than a
+// couple of tiles diagonally; walking the grid crossings instead keeps
+// the per-road tile count proportional to the tiles it passes through.
+func addLineToTiles(lonLat [][2]float64, zoom int, tileFor func(z, x, y uint32) *tileBuilder, road Road, add func(t *tileBuilder, geom []uint32)) {
+	n := uint32(1) << uint(zoom)
+	frac := make([][2]float64, len(lonLat))
+	for i, p := range lonLat {
+		fx, fy := lonLatToTileFrac(p[0], p[1], zoom)
+		frac[i] = [2]float64{fx, fy}
+	}
+
+	var curTX, curTY uint32
+	var curPoints [][2]int32
+	haveCur := false
+
+	toLocal := func(tx, ty uint32, fx, fy float64) [2]int32 {
+		return [2]int32{
+			int32(math.Round((fx - float64(tx)) * tileExtent)),
+			int32(math.Round((fy - float64(ty)) * tileExtent)),
+		}
+	}
+
+	flush := func() {
+		if haveCur && len(curPoints) >= 2 {
+			add(tileFor(uint32(zoom), curTX, curTY), encodeLine(curPoints))
+		}
+		curPoints = nil
+		haveCur = false
+	}
+
+	startTile := func(tx, ty uint32, fx, fy float64) {
+		flush()
+		curTX, curTY = tx, ty
+		haveCur = true
+		curPoints = append(curPoints, toLocal(tx, ty, fx, fy))
+	}
+
+	appendTo := func(fx, fy float64) {
+		curPoints = append(curPoints, toLocal(curTX, curTY, fx, fy))
+	}
+
+	tileIndex := func(f float64) uint32 {
+		return clampTileIndex(int64(math.Floor(f)), n)
+	}
+
+	startTile(tileIndex(frac[0][0]), tileIndex(frac[0][1]), frac[0][0], frac[0][1])
+
+	for i := 1; i < len(frac); i++ {
+		p0, p1 := frac[i-1], frac[i]
+		for _, t := range gridCrossings(p0, p1) {
+			fx := p0[0] + (p1[0]-p0[0])*t
+			fy := p0[1] + (p1[1]-p0[1])*t
+			appendTo(fx, fy)
+
+			// Nudge a hair past the crossing so the next tile index is
+			// unambiguous (landing exactly on an integer boundary could
+			// round to either neighboring cell).
+			const nudge = 1e-9
+			nfx := p0[0] + (p1[0]-p0[0])*(t+nudge)
+			nfy := p0[1] + (p1[1]-p0[1])*(t+nudge)
+			startTile(tileIndex(nfx), tileIndex(nfy), fx, fy)
+		}
+		appendTo(p1[0], p1[1])
+	}
+
+	flush()
+}
+
+// clampTileIndex keeps a tile coordinate within [0, n-1]; simplified
+// geometry can nudge a point a hair outside the valid Mercator range
+// right at the poles or the antimeridian.
+func clampTileIndex(i int64, n uint32) uint32 {
+	if i < 0 {
+		return 0
+	}
+	if i >= int64(n) {
+		return n - 1
+	}
+	return uint32(i)
+}
+
+// gridCrossings returns, in increasing order, the parametric position
+// (0,1) of every integer tile-grid line the segment p0->p1 crosses.
+func gridCrossings(p0, p1 [2]float64) []float64 {
+	var ts []float64
+
+	addAxis := func(a0, a1 float64) {
+		da := a1 - a0
+		if da == 0 {
+			return
+		}
+		lo, hi := a0, a1
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		for k := math.Floor(lo) + 1; k < hi; k++ {
+			t := (k - a0) / da
+			if t > 1e-9 && t < 1-1e-9 {
+				ts = append(ts, t)
+			}
+		}
+	}
+
+	addAxis(p0[0], p1[0])
+	addAxis(p0[1], p1[1])
+	sort.Float64s(ts)
+	return ts
+}
+
+// simplify runs Douglas-Peucker line simplification on lon/lat points.
+func simplify(points [][2]float64, tolerance float64) [][2]float64 {
+	if len(points) < 3 {
+		return points
+	}
+
+	end := len(points) - 1
+	dMax := 0.0
+	index := 0
+	for i := 1; i < end; i++ {
+		d := perpendicularDistance(points[i], points[0], points[end])
+		if d > dMax {
+			index = i
+			dMax = d
+		}
+	}
+
+	if dMax > tolerance {
+		left := simplify(points[:index+1], tolerance)
+		right := simplify(points[index:], tolerance)
+		return append(left[:len(left)-1], right...)
+	}
+
+	return [][2]float64{points[0], points[end]}
+}
+
+func perpendicularDistance(p, a, b [2]float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p[0]-a[0], p[1]-a[1])
+	}
+	t := ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / (dx*dx + dy*dy)
+	projX, projY := a[0]+t*dx, a[1]+t*dy
+	return math.Hypot(p[0]-projX, p[1]-projY)
+}
+
+type pmTileEntry struct {
+	tileID uint64
+	offset uint64
+	length uint64
+}
+
+func assemblePMTiles(path string, tiles map[tileKey]*tileBuilder, maxZoom int) error {
+	keys := make([]tileKey, 0, len(tiles))
+	for k := range tiles {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return zxyToTileID(keys[i].z, keys[i].x, keys[i].y) < zxyToTileID(keys[j].z, keys[j].x, keys[j].y)
+	})
+
+	var tileData bytes.Buffer
+	entries := make([]pmTileEntry, 0, len(keys))
+
+	for _, k := range keys {
+		t := tiles[k]
+		raw := encodeTile([]*mvtLayer{t.roads, t.pois})
+		compressed := gzipBytes(raw)
+
+		entries = append(entries, pmTileEntry{
+			tileID: zxyToTileID(k.z, k.x, k.y),
+			offset: uint64(tileData.Len()),
+			length: uint64(len(compressed)),
+		})
+		tileData.Write(compressed)
+	}
+
+	rootDir := encodeDirectory(entries)
+	metadata := gzipBytes([]byte(`{"name":"Mindanao Truck Simulator roads"}`))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rootDirOffset := uint64(pmtilesHeaderSize)
+	metadataOffset := rootDirOffset + uint64(len(rootDir))
+	leafDirsOffset := metadataOffset + uint64(len(metadata))
+	tileDataOffset := leafDirsOffset // no leaf directories; root dir holds every entry
+
+	header := make([]byte, pmtilesHeaderSize)
+	copy(header[0:7], "PMTiles")
+	header[7] = 3
+	binary.LittleEndian.PutUint64(header[8:], rootDirOffset)
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(rootDir)))
+	binary.LittleEndian.PutUint64(header[24:], metadataOffset)
+	binary.LittleEndian.PutUint64(header[32:], uint64(len(metadata)))
+	binary.LittleEndian.PutUint64(header[40:], leafDirsOffset)
+	binary.LittleEndian.PutUint64(header[48:], 0) // leaf dir length
+	binary.LittleEndian.PutUint64(header[56:], tileDataOffset)
+	binary.LittleEndian.PutUint64(header[64:], uint64(tileData.Len()))
+	binary.LittleEndian.PutUint64(header[72:], uint64(len(entries))) // addressed tiles
+	binary.LittleEndian.PutUint64(header[80:], uint64(len(entries))) // tile entries
+	binary.LittleEndian.PutUint64(header[88:], uint64(len(entries))) // tile contents (no dedup)
+	header[96] = 1                                                   // clustered
+	header[97] = pmCompressionGzip                                   // internal compression
+	header[98] = pmCompressionGzip                                   // tile compression
+	header[99] = pmTileTypeMVT
+	header[100] = 0 // min zoom
+	header[101] = byte(maxZoom)
+	binary.LittleEndian.PutUint32(header[102:], uint32(int32((config.Origin.Lon-5)*1e7)))
+	binary.LittleEndian.PutUint32(header[106:], uint32(int32((config.Origin.Lat-5)*1e7)))
+	binary.LittleEndian.PutUint32(header[110:], uint32(int32((config.Origin.Lon+5)*1e7)))
+	binary.LittleEndian.PutUint32(header[114:], uint32(int32((config.Origin.Lat+5)*1e7)))
+	header[118] = byte(maxZoom / 2)
+	binary.LittleEndian.PutUint32(header[119:], uint32(int32(config.Origin.Lon*1e7)))
+	binary.LittleEndian.PutUint32(header[123:], uint32(int32(config.Origin.Lat*1e7)))
+
+	if _, err := f.Write(header); err != nil {
+		return err
+	}
+	if _, err := f.Write(rootDir); err != nil {
+		return err
+	}
+	if _, err := f.Write(metadata); err != nil {
+		return err
+	}
+	if _, err := f.Write(tileData.Bytes()); err != nil {
+		return err
+	}
+
+	fmt.Printf("  Wrote %d tiles to %s\n", len(entries), path)
+	return nil
+}
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	gz.Close()
+	return buf.Bytes()
+}
+
+// encodeDirectory packs tile entries as delta-encoded tile IDs, run
+// lengths, byte lengths, and offsets (0 meaning "contiguous with the
+// previous entry"), gzip-compressed, matching the PMTiles v3 directory
+// format. entries must already be sorted by tileID.
+func encodeDirectory(entries []pmTileEntry) []byte {
+	w := &pbWriter{}
+	w.varint(uint64(len(entries)))
+
+	var lastID uint64
+	for _, e := range entries {
+		w.varint(e.tileID - lastID)
+		lastID = e.tileID
+	}
+	for range entries {
+		w.varint(1) // run length; we never de-duplicate identical tiles
+	}
+	for _, e := range entries {
+		w.varint(e.length)
+	}
+
+	var lastOffset, lastLength uint64
+	for i, e := range entries {
+		if i > 0 && e.offset == lastOffset+lastLength {
+			w.varint(0)
+		} else {
+			w.varint(e.offset + 1)
+		}
+		lastOffset, lastLength = e.offset, e.length
+	}
+
+	return gzipBytes(w.buf)
+}
+
+// zxyToTileID maps a z/x/y tile coordinate to the single monotonically
+// increasing ID PMTiles indexes by: the count of tiles in all smaller
+// zoom levels, plus this tile's position on a Hilbert curve within its
+// own zoom level (so nearby tiles end up near each other in the file).
+func zxyToTileID(z, x, y uint32) uint64 {
+	var acc uint64
+	for tz := uint32(0); tz < z; tz++ {
+		acc += uint64(1) << (2 * tz)
+	}
+	return acc + hilbertXYToD(uint32(1)<<z, x, y)
+}
+
+func hilbertXYToD(n, x, y uint32) uint64 {
+	var d uint64
+	for s := n / 2; s > 0; s /= 2 {
+		var rx, ry uint32
+		if x&s > 0 {
+			rx = 1
+		}
+		if y&s > 0 {
+			ry = 1
+		}
+		d += uint64(s) * uint64(s) * uint64((3*rx)^ry)
+
+		if ry == 0 {
+			if rx == 1 {
+				x = s - 1 - x
+				y = s - 1 - y
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
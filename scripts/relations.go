@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// ringEpsilon is how close two segment endpoints in game-coord meters
+// must be to count as the same node when stitching rings together.
+const ringEpsilon = 0.01
+
+// processRelationsParallel turns outer-way members of relations into
+// closed polygons: admin_level=8 and place=city|town|municipality
+// relations become POIs at their polygon centroid (common in the
+// Philippines, where municipalities are mapped as boundary relations
+// rather than single nodes), and any relation carrying an admin_level,
+// landuse or building tag is also recorded as a Boundary for
+// boundaries.json (see boundaryLevel).
+func processRelationsParallel(relChunks <-chan []OSMRelation, wayGeoms map[int64][][]float64, elevIndex *ElevationIndex, srtm *SRTM, numWorkers int) ([]POI, []Boundary) {
+	var pois []POI
+	var poisMutex sync.Mutex
+
+	var boundaries []Boundary
+	var boundariesMutex sync.Mutex
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var localPOIs []POI
+			var localBoundaries []Boundary
+
+			for chunk := range relChunks {
+				for _, rel := range chunk {
+					boundaries, poi, ok := processRelation(rel, wayGeoms, elevIndex, srtm)
+					localBoundaries = append(localBoundaries, boundaries...)
+					if ok {
+						localPOIs = append(localPOIs, poi)
+					}
+				}
+			}
+
+			poisMutex.Lock()
+			pois = append(pois, localPOIs...)
+			poisMutex.Unlock()
+
+			boundariesMutex.Lock()
+			boundaries = append(boundaries, localBoundaries...)
+			boundariesMutex.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return pois, boundaries
+}
+
+// processRelation assembles a relation's outer way members into rings
+// and returns one Boundary per ring it assembles (a relation with island
+// exclaves, common for Philippine municipalities, yields several rings
+// that all share the relation's ID/level/name) plus, for municipality
+// relations, a single POI at the centroid of the whole territory.
+func processRelation(rel OSMRelation, wayGeoms map[int64][][]float64, elevIndex *ElevationIndex, srtm *SRTM) ([]Boundary, POI, bool) {
+	tags := make(map[string]string)
+	for _, tag := range rel.Tags {
+		tags[tag.K] = tag.V
+	}
+
+	var outerSegments [][][]float64
+	for _, m := range rel.Members {
+		if m.Type != "way" || m.Role != "outer" {
+			continue
+		}
+		if points, ok := wayGeoms[m.Ref]; ok && len(points) >= 2 {
+			outerSegments = append(outerSegments, points)
+		}
+	}
+	if len(outerSegments) == 0 {
+		return nil, POI{}, false
+	}
+
+	rings, incomplete := assembleRings(outerSegments)
+	if incomplete > 0 {
+		fmt.Printf("  Warning: relation %d has %d incomplete outer ring(s), skipped\n", rel.ID, incomplete)
+	}
+	if len(rings) == 0 {
+		return nil, POI{}, false
+	}
+
+	var name *string
+	if n, ok := tags["name"]; ok {
+		name = &n
+	}
+
+	var boundaries []Boundary
+	if level, ok := boundaryLevel(tags); ok {
+		id := strconv.FormatInt(rel.ID, 10)
+		for i, ring := range rings {
+			ringID := id
+			if len(rings) > 1 {
+				ringID = fmt.Sprintf("%s-%d", id, i)
+			}
+			boundaries = append(boundaries, Boundary{
+				ID:    ringID,
+				Level: level,
+				Name:  name,
+				Ring:  ring,
+			})
+		}
+	}
+
+	place := tags["place"]
+	isMunicipality := tags["admin_level"] == "8" || place == "city" || place == "town" || place == "municipality"
+	if !isMunicipality {
+		return boundaries, POI{}, false
+	}
+
+	cx, cz := combinedRingsCentroid(rings)
+	lat, lon := fromGameCoords(cx, cz)
+	ele := resolveCentroidElevation(lat, lon, srtm, elevIndex)
+
+	poiType := place
+	if poiType == "" {
+		poiType = "municipality"
+	}
+
+	poi := POI{
+		ID:   strconv.FormatInt(rel.ID, 10),
+		Type: poiType,
+		Name: name,
+		X:    cx,
+		Y:    ele,
+		Z:    cz,
+	}
+	return boundaries, poi, true
+}
+
+// boundaryLevel decides whether a relation's outer ring is worth
+// recording in boundaries.json and, if so, what level to tag it with.
+// Administrative boundaries use their admin_level tag as before;
+// landuse and building multipolygons (which carry no admin_level) are
+// recorded too, tagged "landuse:<value>"/"building:<value>" so the
+// output distinguishes them from actual admin boundaries.
+func boundaryLevel(tags map[string]string) (string, bool) {
+	if level, ok := tags["admin_level"]; ok {
+		return level, true
+	}
+	if landuse, ok := tags["landuse"]; ok {
+		return "landuse:" + landuse, true
+	}
+	if building, ok := tags["building"]; ok {
+		return "building:" + building, true
+	}
+	return "", false
+}
+
+// assembleRings stitches possibly-unordered way segments into closed
+// polygon rings by repeatedly matching endpoints, the way multipolygon
+// builders like imposm3 do. Segments that never close are dropped and
+// counted so the caller can warn instead of failing outright.
+func assembleRings(segments [][][]float64) ([][][]float64, int) {
+	used := make([]bool, len(segments))
+	var rings [][][]float64
+	incomplete := 0
+
+	for i := range segments {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		ring := append([][]float64{}, segments[i]...)
+
+		for !pointsMatch(ring[0], ring[len(ring)-1]) {
+			extended := false
+			for j := range segments {
+				if used[j] {
+					continue
+				}
+				seg := segments[j]
+				tail := ring[len(ring)-1]
+
+				if pointsMatch(tail, seg[0]) {
+					ring = append(ring, seg[1:]...)
+					used[j] = true
+					extended = true
+					break
+				}
+				if pointsMatch(tail, seg[len(seg)-1]) {
+					ring = append(ring, reverseSegment(seg)[1:]...)
+					used[j] = true
+					extended = true
+					break
+				}
+			}
+			if !extended {
+				break
+			}
+		}
+
+		if len(ring) >= 4 && pointsMatch(ring[0], ring[len(ring)-1]) {
+			rings = append(rings, ring)
+		} else {
+			incomplete++
+		}
+	}
+
+	return rings, incomplete
+}
+
+func reverseSegment(seg [][]float64) [][]float64 {
+	reversed := make([][]float64, len(seg))
+	for i, p := range seg {
+		reversed[len(seg)-1-i] = p
+	}
+	return reversed
+}
+
+func pointsMatch(a, b []float64) bool {
+	return math.Abs(a[0]-b[0]) < ringEpsilon && math.Abs(a[2]-b[2]) < ringEpsilon
+}
+
+// ringCentroid returns the area-weighted centroid of a closed ring in
+// game-coord (x, z) space.
+func ringCentroid(ring [][]float64) (float64, float64) {
+	cx, cz, _ := ringCentroidAndArea(ring)
+	return cx, cz
+}
+
+// ringCentroidAndArea is ringCentroid but also returns the ring's signed
+// area, so callers combining several rings (e.g. a multi-island
+// municipality) can weight each ring's centroid by how much territory it
+// actually covers.
+func ringCentroidAndArea(ring [][]float64) (cx, cz, area float64) {
+	for i := 0; i < len(ring)-1; i++ {
+		x0, z0 := ring[i][0], ring[i][2]
+		x1, z1 := ring[i+1][0], ring[i+1][2]
+		cross := x0*z1 - x1*z0
+		area += cross
+		cx += (x0 + x1) * cross
+		cz += (z0 + z1) * cross
+	}
+	area /= 2
+
+	if area == 0 {
+		var sx, sz float64
+		for _, p := range ring[:len(ring)-1] {
+			sx += p[0]
+			sz += p[2]
+		}
+		n := float64(len(ring) - 1)
+		return sx / n, sz / n, 0
+	}
+
+	return cx / (6 * area), cz / (6 * area), area
+}
+
+// combinedRingsCentroid returns the centroid of several disjoint rings
+// belonging to the same relation (e.g. a municipality with island
+// exclaves), weighting each ring's centroid by its area so larger
+// fragments of the territory pull the result toward them.
+func combinedRingsCentroid(rings [][][]float64) (float64, float64) {
+	if len(rings) == 1 {
+		return ringCentroid(rings[0])
+	}
+
+	var cx, cz, totalWeight float64
+	for _, ring := range rings {
+		x, z, area := ringCentroidAndArea(ring)
+		weight := math.Abs(area)
+		if weight == 0 {
+			weight = 1
+		}
+		cx += x * weight
+		cz += z * weight
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return 0, 0
+	}
+	return cx / totalWeight, cz / totalWeight
+}
+
+func resolveCentroidElevation(lat, lon float64, srtm *SRTM, elevIndex *ElevationIndex) float64 {
+	if srtm != nil {
+		if ele, ok := srtm.Elevation(lat, lon); ok {
+			return ele
+		}
+	}
+	if elevIndex != nil {
+		return elevIndex.Lookup(lat, lon)
+	}
+	return 0
+}
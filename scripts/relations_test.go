@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestAssembleRingsStitchesUnorderedSegments(t *testing.T) {
+	// A right triangle, supplied as three separate way segments out of
+	// order (the third follows the first, and the second is reversed)
+	// that only close into a ring once stitched.
+	segments := [][][]float64{
+		{{10, 0, 10}, {0, 0, 0}},
+		{{10, 0, 10}, {10, 0, 0}},
+		{{0, 0, 0}, {10, 0, 0}},
+	}
+
+	rings, incomplete := assembleRings(segments)
+	if incomplete != 0 {
+		t.Fatalf("got %d incomplete rings, want 0", incomplete)
+	}
+	if len(rings) != 1 {
+		t.Fatalf("got %d rings, want 1", len(rings))
+	}
+
+	ring := rings[0]
+	if !pointsMatch(ring[0], ring[len(ring)-1]) {
+		t.Fatalf("ring does not close: starts at %v, ends at %v", ring[0], ring[len(ring)-1])
+	}
+}
+
+func TestAssembleRingsReportsIncompleteRing(t *testing.T) {
+	// Two dangling segments, neither of which reconnects to its start.
+	segments := [][][]float64{
+		{{0, 0, 0}, {10, 0, 0}},
+		{{20, 0, 0}, {30, 0, 10}},
+	}
+
+	rings, incomplete := assembleRings(segments)
+	if incomplete != 2 {
+		t.Fatalf("got %d incomplete rings, want 2", incomplete)
+	}
+	if len(rings) != 0 {
+		t.Fatalf("got %d rings, want 0", len(rings))
+	}
+}
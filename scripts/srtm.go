@@ -0,0 +1,178 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// srtmTileSize is the sample grid dimension of a 1-arc-second SRTM tile
+// (3601x3601, with a one-sample overlap shared by adjacent tiles).
+const srtmTileSize = 3601
+
+// srtmVoid is the sentinel value SRTM tiles use for missing data.
+const srtmVoid = -32768
+
+// SRTM resolves lat/lon elevation from a directory of 1-arc-second .hgt
+// (or .hgt.gz) tiles, used to fill in terrain where OSM `ele` tags are
+// sparse. Tiles are loaded lazily and cached for the life of the run.
+type SRTM struct {
+	dir string
+
+	mu    sync.Mutex
+	tiles map[string]*srtmTile
+}
+
+type srtmTile struct {
+	samples []int16
+}
+
+// NewSRTM returns an SRTM reader rooted at dir. Missing or malformed
+// tiles are not an error here; they simply produce no match on lookup.
+func NewSRTM(dir string) *SRTM {
+	return &SRTM{dir: dir, tiles: make(map[string]*srtmTile)}
+}
+
+func srtmTileName(lat, lon float64) string {
+	latFloor := int(math.Floor(lat))
+	lonFloor := int(math.Floor(lon))
+
+	nsHemi := "N"
+	if latFloor < 0 {
+		nsHemi = "S"
+	}
+	ewHemi := "E"
+	if lonFloor < 0 {
+		ewHemi = "W"
+	}
+
+	return fmt.Sprintf("%s%02d%s%03d.hgt", nsHemi, abs(latFloor), ewHemi, abs(lonFloor))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (s *SRTM) tile(name string) (*srtmTile, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.tiles[name]; ok {
+		return t, t != nil
+	}
+
+	samples, err := loadHGT(filepath.Join(s.dir, name))
+	if err != nil {
+		samples, err = loadHGTGz(filepath.Join(s.dir, name+".gz"))
+	}
+	if err != nil {
+		s.tiles[name] = nil
+		return nil, false
+	}
+
+	t := &srtmTile{samples: samples}
+	s.tiles[name] = t
+	return t, true
+}
+
+func loadHGT(path string) ([]int16, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeHGT(raw)
+}
+
+func loadHGTGz(path string) ([]int16, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	return decodeHGT(raw)
+}
+
+func decodeHGT(raw []byte) ([]int16, error) {
+	expected := srtmTileSize * srtmTileSize * 2
+	if len(raw) != expected {
+		return nil, fmt.Errorf("unexpected SRTM tile size: got %d bytes, want %d", len(raw), expected)
+	}
+
+	samples := make([]int16, srtmTileSize*srtmTileSize)
+	for i := range samples {
+		samples[i] = int16(binary.BigEndian.Uint16(raw[i*2:]))
+	}
+	return samples, nil
+}
+
+// sampleAt returns the raw sample at (row, col), or false if it falls
+// outside the tile or is a void cell.
+func (t *srtmTile) sampleAt(row, col int) (float64, bool) {
+	if row < 0 || row >= srtmTileSize || col < 0 || col >= srtmTileSize {
+		return 0, false
+	}
+	v := t.samples[row*srtmTileSize+col]
+	if v == srtmVoid {
+		return 0, false
+	}
+	return float64(v), true
+}
+
+// Elevation returns the bilinearly-interpolated elevation at (lat, lon),
+// or false if the covering tile is missing or the sample is a void cell.
+func (s *SRTM) Elevation(lat, lon float64) (float64, bool) {
+	t, ok := s.tile(srtmTileName(lat, lon))
+	if !ok {
+		return 0, false
+	}
+
+	fracLat := lat - math.Floor(lat)
+	fracLon := lon - math.Floor(lon)
+
+	row := (1 - fracLat) * float64(srtmTileSize-1)
+	col := fracLon * float64(srtmTileSize-1)
+
+	row0 := int(math.Floor(row))
+	col0 := int(math.Floor(col))
+	row1 := row0 + 1
+	col1 := col0 + 1
+	// At an integer latitude row0 is already the tile's last row and
+	// dRow is 0, so the row below is not needed.
+	if row1 >= srtmTileSize {
+		row1 = row0
+	}
+
+	v00, ok00 := t.sampleAt(row0, col0)
+	v01, ok01 := t.sampleAt(row0, col1)
+	v10, ok10 := t.sampleAt(row1, col0)
+	v11, ok11 := t.sampleAt(row1, col1)
+	if !ok00 || !ok01 || !ok10 || !ok11 {
+		return 0, false
+	}
+
+	dRow := row - float64(row0)
+	dCol := col - float64(col0)
+
+	top := v00*(1-dCol) + v01*dCol
+	bottom := v10*(1-dCol) + v11*dCol
+	return top*(1-dRow) + bottom*dRow, true
+}